@@ -1,21 +1,86 @@
 package mysql
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
 type Config struct {
-	Host     string
-	Port     string
-	Database string
-	Username string
-	Password string
-	Charset  string
-	Prefix   string
-	useDb    bool
-	Debug    bool
-	Explain  bool
+	Host                   string
+	Hosts                  []string
+	Port                   string
+	Socket                 string
+	Database               string
+	Username               string
+	Password               string
+	Charset                string
+	Collation              string
+	Prefix                 string
+	useDb                  bool
+	Debug                  bool
+	Explain                bool
+	DryRun                 bool
+	ParseTime              bool
+	Loc                    string
+	Timeout                time.Duration
+	ReadTimeout            time.Duration
+	WriteTimeout           time.Duration
+	TLS                    string
+	TLSCA                  string
+	TLSCert                string
+	TLSKey                 string
+	DisableNativePasswords bool
+	InterpolateParams      bool
+	Params                 map[string]string
+	MaxOpenConns           int
+	MaxIdleConns           int
+	ConnMaxLifetime        time.Duration
+	ConnMaxIdleTime        time.Duration
+	Replicas               []Replica
+	MaxReplicaLag          time.Duration
+	HealthCheckInterval    time.Duration
+	Retry                  RetryPolicy
+	MaskColumns            []string
+	SlowThreshold          time.Duration
+	SlowHook               func(SlowQuery)
 }
 
-func (cfg *Config) Configure() *Config {
+func (cfg *Config) Validate() error {
 	if cfg.Database == "" {
-		logger.Fatal("Database does not exist")
+		return errors.New("Database does not exist")
+	}
+
+	if cfg.Port != "" {
+		if _, err := strconv.Atoi(cfg.Port); err != nil {
+			return errors.New("invalid port " + cfg.Port)
+		}
+	}
+
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return errors.New("TLSCert and TLSKey must be set together")
+	}
+
+	if cfg.MaxOpenConns < 0 || cfg.ConnMaxLifetime < 0 || cfg.ConnMaxIdleTime < 0 {
+		return errors.New("pool settings must not be negative")
+	}
+
+	if cfg.Timeout < 0 || cfg.ReadTimeout < 0 || cfg.WriteTimeout < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+
+	return nil
+}
+
+func (cfg *Config) Configure() *Config {
+	if err := cfg.Validate(); err != nil {
+		fatal(err)
 	}
 
 	if cfg.Host == "" {
@@ -30,9 +95,105 @@ func (cfg *Config) Configure() *Config {
 		cfg.Username = "root"
 	}
 
-	if cfg.Charset == "" {
+	if cfg.Charset == "" && cfg.Collation == "" {
 		cfg.Charset = "utf8"
 	}
 
 	return cfg
 }
+
+func (cfg *Config) tlsConfig() (string, error) {
+	if cfg.TLSCA == "" && cfg.TLSCert == "" && cfg.TLSKey == "" {
+		return cfg.TLS, nil
+	}
+
+	tc := &tls.Config{
+		ServerName:         cfg.Host,
+		InsecureSkipVerify: cfg.TLS == "skip-verify",
+	}
+
+	if cfg.TLSCA != "" {
+		pem, err := ioutil.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return "", err
+		}
+
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return "", errors.New("failed to append CA certificate " + cfg.TLSCA)
+		}
+	}
+
+	if cfg.TLSCert != "" || cfg.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return "", err
+		}
+
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	name := "custom-" + cfg.Host + "-" + cfg.Port
+	if err := driver.RegisterTLSConfig(name, tc); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+func (cfg *Config) FormatDSN() (string, error) {
+	dc := driver.NewConfig()
+
+	dc.User   = cfg.Username
+	dc.Passwd = cfg.Password
+
+	if cfg.Socket != "" {
+		dc.Net  = "unix"
+		dc.Addr = cfg.Socket
+	} else {
+		dc.Net  = "tcp"
+		dc.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	}
+
+	if cfg.useDb {
+		dc.DBName = cfg.Database
+	}
+
+	dc.Params = make(map[string]string)
+
+	if cfg.Charset != "" {
+		dc.Params["charset"] = cfg.Charset
+	}
+
+	for k, v := range cfg.Params {
+		dc.Params[k] = v
+	}
+
+	if cfg.Collation != "" {
+		dc.Collation = cfg.Collation
+	}
+
+	if cfg.Loc != "" {
+		loc, err := time.LoadLocation(cfg.Loc)
+		if err != nil {
+			return "", err
+		}
+
+		dc.Loc = loc
+	}
+
+	name, err := cfg.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+
+	dc.TLSConfig            = name
+	dc.ParseTime            = cfg.ParseTime
+	dc.Timeout              = cfg.Timeout
+	dc.ReadTimeout          = cfg.ReadTimeout
+	dc.WriteTimeout         = cfg.WriteTimeout
+	dc.AllowNativePasswords = !cfg.DisableNativePasswords
+	dc.InterpolateParams    = cfg.InterpolateParams
+
+	return dc.FormatDSN(), nil
+}
@@ -0,0 +1,72 @@
+package mysql
+
+import "sync"
+
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	stmts []Statement
+}
+
+func (s Statement) String() string {
+	return Interpolate(s.SQL, s.Args)
+}
+
+func (db *DB) DryRun() *DB {
+	if db.recorder == nil {
+		db.recorder = &recorder{}
+	}
+
+	q := db.fork()
+	q.dry = true
+
+	return q
+}
+
+func (db *DB) dryRun() bool {
+	return db.dry || db.config.DryRun
+}
+
+func (db *DB) record(query string, args []interface{}) {
+	if db.recorder == nil {
+		db.recorder = &recorder{}
+	}
+
+	db.last = query
+
+	if db.config.Debug {
+		db.logger().Debug("dry run", "sql", query, "args", db.maskArgs(query, args))
+	}
+
+	db.recorder.mu.Lock()
+	db.recorder.stmts = append(db.recorder.stmts, Statement{SQL: query, Args: args})
+	db.recorder.mu.Unlock()
+}
+
+func (db *DB) Statements() []Statement {
+	if db.recorder == nil {
+		return nil
+	}
+
+	db.recorder.mu.Lock()
+	defer db.recorder.mu.Unlock()
+
+	res := make([]Statement, len(db.recorder.stmts))
+	copy(res, db.recorder.stmts)
+
+	return res
+}
+
+func (db *DB) ResetStatements() {
+	if db.recorder == nil {
+		return
+	}
+
+	db.recorder.mu.Lock()
+	db.recorder.stmts = nil
+	db.recorder.mu.Unlock()
+}
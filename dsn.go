@@ -0,0 +1,391 @@
+package mysql
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+var envKeys = map[string]string{
+	"HOST":                     "host",
+	"HOSTS":                    "hosts",
+	"PORT":                     "port",
+	"SOCKET":                   "socket",
+	"DATABASE":                 "database",
+	"USERNAME":                 "username",
+	"PASSWORD":                 "password",
+	"CHARSET":                  "charset",
+	"COLLATION":                "collation",
+	"PREFIX":                   "prefix",
+	"DEBUG":                    "debug",
+	"EXPLAIN":                  "explain",
+	"DRY_RUN":                  "dryRun",
+	"PARSE_TIME":               "parseTime",
+	"LOC":                      "loc",
+	"TIMEOUT":                  "timeout",
+	"READ_TIMEOUT":             "readTimeout",
+	"WRITE_TIMEOUT":            "writeTimeout",
+	"TLS":                      "tls",
+	"TLS_CA":                   "tlsCA",
+	"TLS_CERT":                 "tlsCert",
+	"TLS_KEY":                  "tlsKey",
+	"DISABLE_NATIVE_PASSWORDS": "disableNativePasswords",
+	"INTERPOLATE_PARAMS":       "interpolateParams",
+	"MAX_OPEN_CONNS":           "maxOpenConns",
+	"MAX_IDLE_CONNS":           "maxIdleConns",
+	"CONN_MAX_LIFETIME":        "connMaxLifetime",
+	"CONN_MAX_IDLE_TIME":       "connMaxIdleTime",
+	"MAX_REPLICA_LAG":          "maxReplicaLag",
+	"HEALTH_CHECK_INTERVAL":    "healthCheckInterval",
+	"RETRY_ATTEMPTS":           "retryAttempts",
+	"RETRY_BASE_DELAY":         "retryBaseDelay",
+	"RETRY_MAX_DELAY":          "retryMaxDelay",
+	"SLOW_THRESHOLD":           "slowThreshold",
+}
+
+var dsnKeys = map[string]bool{
+	"hosts":               true,
+	"socket":              true,
+	"prefix":              true,
+	"debug":               true,
+	"explain":             true,
+	"dryRun":              true,
+	"tlsCA":               true,
+	"tlsCert":             true,
+	"tlsKey":              true,
+	"maxOpenConns":        true,
+	"maxIdleConns":        true,
+	"connMaxLifetime":     true,
+	"connMaxIdleTime":     true,
+	"maxReplicaLag":       true,
+	"healthCheckInterval": true,
+	"retryAttempts":       true,
+	"retryBaseDelay":      true,
+	"retryMaxDelay":       true,
+	"slowThreshold":       true,
+}
+
+var driverKeys = map[string]bool{
+	"allowAllFiles":           true,
+	"allowCleartextPasswords": true,
+	"allowOldPasswords":       true,
+	"checkConnLiveness":       true,
+	"clientFoundRows":         true,
+	"columnsWithAlias":        true,
+	"maxAllowedPacket":        true,
+	"multiStatements":         true,
+	"rejectReadOnly":          true,
+	"serverPubKey":            true,
+}
+
+func (cfg *Config) set(k, v string) (err error) {
+	var (
+		b bool
+		i int
+		d time.Duration
+	)
+
+	switch k {
+	case "debug", "explain", "dryRun", "parseTime", "disableNativePasswords", "interpolateParams":
+		b, err = strconv.ParseBool(v)
+	case "maxOpenConns", "maxIdleConns", "retryAttempts":
+		i, err = strconv.Atoi(v)
+	case "timeout", "readTimeout", "writeTimeout", "connMaxLifetime", "connMaxIdleTime", "maxReplicaLag", "healthCheckInterval", "retryBaseDelay", "retryMaxDelay", "slowThreshold":
+		d, err = time.ParseDuration(v)
+	}
+
+	if err != nil {
+		return errors.New("invalid value for " + k + ": " + v)
+	}
+
+	switch k {
+	case "host":
+		cfg.Host = v
+	case "hosts":
+		cfg.Hosts = nil
+
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				cfg.Hosts = append(cfg.Hosts, h)
+			}
+		}
+	case "port":
+		cfg.Port = v
+	case "socket":
+		cfg.Socket = v
+	case "database":
+		cfg.Database = v
+	case "username":
+		cfg.Username = v
+	case "password":
+		cfg.Password = v
+	case "charset":
+		cfg.Charset = v
+	case "collation":
+		cfg.Collation = v
+	case "prefix":
+		cfg.Prefix = v
+	case "debug":
+		cfg.Debug = b
+	case "explain":
+		cfg.Explain = b
+	case "dryRun":
+		cfg.DryRun = b
+	case "parseTime":
+		cfg.ParseTime = b
+	case "loc":
+		cfg.Loc = v
+	case "timeout":
+		cfg.Timeout = d
+	case "readTimeout":
+		cfg.ReadTimeout = d
+	case "writeTimeout":
+		cfg.WriteTimeout = d
+	case "tls":
+		cfg.TLS = v
+	case "tlsCA":
+		cfg.TLSCA = v
+	case "tlsCert":
+		cfg.TLSCert = v
+	case "tlsKey":
+		cfg.TLSKey = v
+	case "disableNativePasswords":
+		cfg.DisableNativePasswords = b
+	case "interpolateParams":
+		cfg.InterpolateParams = b
+	case "maxOpenConns":
+		cfg.MaxOpenConns = i
+	case "maxIdleConns":
+		cfg.MaxIdleConns = i
+	case "connMaxLifetime":
+		cfg.ConnMaxLifetime = d
+	case "connMaxIdleTime":
+		cfg.ConnMaxIdleTime = d
+	case "maxReplicaLag":
+		cfg.MaxReplicaLag = d
+	case "healthCheckInterval":
+		cfg.HealthCheckInterval = d
+	case "retryAttempts":
+		cfg.Retry.MaxAttempts = i
+	case "retryBaseDelay":
+		cfg.Retry.BaseDelay = d
+	case "retryMaxDelay":
+		cfg.Retry.MaxDelay = d
+	case "slowThreshold":
+		cfg.SlowThreshold = d
+	default:
+		return errors.New("unknown config key " + k)
+	}
+
+	return nil
+}
+
+func ParseDSN(dsn string) (*Config, error) {
+	cfg, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func parseDSN(dsn string) (*Config, error) {
+	cfg := &Config{}
+
+	var rest []string
+
+	if i := strings.LastIndex(dsn, "/"); i >= 0 {
+		if j := strings.Index(dsn[i:], "?"); j >= 0 {
+			for _, v := range strings.Split(dsn[i+j+1:], "&") {
+				kv := strings.SplitN(v, "=", 2)
+
+				if len(kv) == 2 && dsnKeys[kv[0]] {
+					val, err := url.QueryUnescape(kv[1])
+					if err != nil {
+						return nil, err
+					}
+
+					if err = cfg.set(kv[0], val); err != nil {
+						return nil, err
+					}
+				} else if v != "" {
+					rest = append(rest, v)
+
+					if len(kv) == 2 && driverKeys[kv[0]] {
+						val, err := url.QueryUnescape(kv[1])
+						if err != nil {
+							return nil, err
+						}
+
+						if cfg.Params == nil {
+							cfg.Params = make(map[string]string)
+						}
+
+						cfg.Params[kv[0]] = val
+					}
+				}
+			}
+
+			dsn = dsn[:i+j]
+
+			if len(rest) > 0 {
+				dsn += "?" + strings.Join(rest, "&")
+			}
+		}
+	}
+
+	dc, err := driver.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Username = dc.User
+	cfg.Password = dc.Passwd
+	cfg.Database = dc.DBName
+
+	if dc.Net == "unix" {
+		cfg.Socket = dc.Addr
+	} else if host, port, err := net.SplitHostPort(dc.Addr); err == nil {
+		cfg.Host = host
+		cfg.Port = port
+	} else {
+		return nil, err
+	}
+
+	for k, v := range dc.Params {
+		if k == "charset" {
+			cfg.Charset = v
+		} else {
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]string)
+			}
+
+			cfg.Params[k] = v
+		}
+	}
+
+	for _, v := range rest {
+		if strings.HasPrefix(v, "collation=") {
+			cfg.Collation = dc.Collation
+		}
+	}
+
+	if dc.Loc != time.UTC {
+		cfg.Loc = dc.Loc.String()
+	}
+
+	cfg.TLS                    = dc.TLSConfig
+	cfg.ParseTime              = dc.ParseTime
+	cfg.Timeout                = dc.Timeout
+	cfg.ReadTimeout            = dc.ReadTimeout
+	cfg.WriteTimeout           = dc.WriteTimeout
+	cfg.DisableNativePasswords = !dc.AllowNativePasswords
+	cfg.InterpolateParams      = dc.InterpolateParams
+
+	return cfg, nil
+}
+
+func ParseURL(s string) (*Config, error) {
+	cfg, err := parseURL(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func parseURL(s string) (*Config, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "mysql" && u.Scheme != "mariadb" {
+		return nil, errors.New("unsupported scheme " + u.Scheme)
+	}
+
+	var (
+		auth  string
+		addr  string
+		query = u.Query()
+	)
+
+	if u.User != nil {
+		auth = u.User.Username()
+
+		if pwd, ok := u.User.Password(); ok {
+			auth += ":" + pwd
+		}
+
+		auth += "@"
+	}
+
+	if socket := query.Get("socket"); socket != "" {
+		addr = "unix(" + socket + ")"
+		query.Del("socket")
+	} else {
+		port := u.Port()
+		if port == "" {
+			port = "3306"
+		}
+
+		addr = "tcp(" + net.JoinHostPort(u.Hostname(), port) + ")"
+	}
+
+	dsn := auth + addr + "/" + strings.TrimPrefix(u.Path, "/")
+
+	if len(query) > 0 {
+		dsn += "?" + query.Encode()
+	}
+
+	return parseDSN(dsn)
+}
+
+func FromEnv(prefix string) (*Config, error) {
+	var (
+		cfg = &Config{}
+		err error
+	)
+
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	if v := os.Getenv(prefix + "URL"); v != "" {
+		if cfg, err = parseURL(v); err != nil {
+			return nil, err
+		}
+	} else if v = os.Getenv(prefix + "DSN"); v != "" {
+		if cfg, err = parseDSN(v); err != nil {
+			return nil, err
+		}
+	}
+
+	for env, k := range envKeys {
+		if v, ok := os.LookupEnv(prefix + env); ok {
+			if err = cfg.set(k, v); err != nil {
+				return nil, errors.New(prefix + env + ": " + err.Error())
+			}
+		}
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
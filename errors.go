@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"errors"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+type Error struct {
+	Table string
+	SQL   string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	msg := e.Err.Error()
+
+	if e.Table != "" {
+		msg += " [table " + e.Table + "]"
+	}
+
+	if e.SQL != "" {
+		msg += " [query " + e.SQL + "]"
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (db *DB) wrap(err error) error {
+	var e *Error
+
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &Error{Table: db.table, SQL: db.last, Err: err}
+}
+
+func ErrorNumber(err error) uint16 {
+	var me *driver.MySQLError
+
+	if errors.As(err, &me) {
+		return me.Number
+	}
+
+	return 0
+}
+
+func isNumber(err error, num ...uint16) bool {
+	n := ErrorNumber(err)
+
+	for i := 0; i < len(num); i++ {
+		if n != 0 && n == num[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func IsDuplicateKey(err error) bool {
+	return isNumber(err, 1062, 1586)
+}
+
+func IsDeadlock(err error) bool {
+	return isNumber(err, 1213)
+}
+
+func IsLockTimeout(err error) bool {
+	return isNumber(err, 1205)
+}
+
+func IsForeignKeyViolation(err error) bool {
+	return isNumber(err, 1216, 1217, 1451, 1452)
+}
+
+func IsTableMissing(err error) bool {
+	return isNumber(err, 1146)
+}
+
+func IsDataTooLong(err error) bool {
+	return isNumber(err, 1406)
+}
+
+func IsReadOnly(err error) bool {
+	return isNumber(err, 1290, 1836)
+}
@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+type primarySet struct {
+	sync.Mutex
+	cfg  *Config
+	db   *sql.DB
+	host int
+}
+
+func isConnError(err error) bool {
+	var ne net.Error
+
+	return errors.Is(err, sqldriver.ErrBadConn) || errors.Is(err, driver.ErrInvalidConn) || errors.As(err, &ne)
+}
+
+func writable(pool *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var readOnly int
+
+	if err := pool.QueryRowContext(ctx, "SELECT @@read_only").Scan(&readOnly); err != nil {
+		return err
+	}
+
+	if readOnly != 0 {
+		return errors.New("server is read only")
+	}
+
+	return nil
+}
+
+func (ps *primarySet) timeout() time.Duration {
+	if ps.cfg.Timeout > 0 {
+		return ps.cfg.Timeout
+	}
+
+	return 5 * time.Second
+}
+
+func (ps *primarySet) resolve(start int, log Logger) (*sql.DB, int, error) {
+	var last error
+
+	for i := 0; i < len(ps.cfg.Hosts); i++ {
+		n := (start + i) % len(ps.cfg.Hosts)
+
+		host, port, err := net.SplitHostPort(ps.cfg.Hosts[n])
+		if err != nil {
+			host, port = ps.cfg.Hosts[n], ps.cfg.Port
+		}
+
+		hc := *ps.cfg
+		hc.Host     = host
+		hc.Port     = port
+		hc.Hosts    = nil
+		hc.Replicas = nil
+
+		pool := openPool(&hc)
+
+		if err = writable(pool, ps.timeout()); err != nil {
+			_ = pool.Close()
+
+			log.Warn("host is not writable", "host", ps.cfg.Hosts[n], "error", err)
+			last = err
+
+			continue
+		}
+
+		return pool, n, nil
+	}
+
+	return nil, 0, errors.New("no writable host: " + last.Error())
+}
+
+func (ps *primarySet) current() *sql.DB {
+	ps.Lock()
+	defer ps.Unlock()
+
+	return ps.db
+}
+
+func (ps *primarySet) close() {
+	if ps == nil {
+		return
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	_ = ps.db.Close()
+}
+
+func (ps *primarySet) failover(stale *sql.DB, log Logger) (*sql.DB, error) {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if ps.db != stale || writable(ps.db, ps.timeout()) == nil {
+		return ps.db, nil
+	}
+
+	pool, n, err := ps.resolve(ps.host + 1, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool != ps.db {
+		_ = ps.db.Close()
+	}
+
+	ps.db, ps.host = pool, n
+
+	log.Warn("primary failed over", "host", ps.cfg.Hosts[n])
+
+	return pool, nil
+}
+
+func openPrimary(cfg *Config) (*sql.DB, *primarySet) {
+	if len(cfg.Hosts) == 0 || cfg.DryRun {
+		return openPool(cfg), nil
+	}
+
+	ps := &primarySet{cfg: cfg}
+
+	pool, n, err := ps.resolve(0, logger)
+	if err != nil {
+		fatal(err)
+	}
+
+	ps.db, ps.host = pool, n
+
+	return pool, ps
+}
+
+func (db *DB) syncPrimary() {
+	if db.primaries != nil && db.tx == nil {
+		db.SQL = db.primaries.current()
+	}
+}
+
+func (db *DB) failover() bool {
+	if db.primaries == nil || db.tx != nil {
+		return false
+	}
+
+	pool, err := db.primaries.failover(db.SQL, db.logger())
+	if err != nil {
+		db.logger().Error("failover failed", "error", err)
+
+		return false
+	}
+
+	db.SQL = pool
+
+	return true
+}
@@ -3,6 +3,8 @@ module github.com/qkofy/mysql
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/qkofy/log v0.3.2
+	gopkg.in/yaml.v3 v3.0.1
 )
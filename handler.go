@@ -1,19 +1,17 @@
 package mysql
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
-
-	"github.com/qkofy/log"
+	"time"
 )
 
-var logger *log.Logger
-
-func init() {
-	logger = log.New(&log.Config{})
-}
-
 func MakeBackQuote(s, sep string) string {
 	tmp := strings.Split(func(s, sep string) string {
 		if sep != " " {
@@ -54,6 +52,16 @@ func MakeBackQuote(s, sep string) string {
 	return strings.Join(tmp, sep)
 }
 
+func FieldName(s string) string {
+	s = strings.TrimSpace(s)
+
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		s = s[i+1:]
+	}
+
+	return strings.Trim(s, "` ")
+}
+
 func ReplaceAll(s string, r ...[2]string) string {
 	for i := 0; i < len(r); i++ {
 		s = strings.Replace(s, r[i][0], r[i][1],-1)
@@ -111,7 +119,7 @@ func ParseWhere(where interface{}, andor string, prm *[]interface{}) string {
 		reg, err := regexp.Compile("(?i)(^|and|or|\\(|\\.)\\s*([a-z0-9_]+)")
 
 		if err != nil {
-			logger.Fatal(err)
+			fatal(err)
 		}
 
 		s = reg.ReplaceAllString(s, "$1 `$2`")
@@ -144,6 +152,165 @@ func ParseWhere(where interface{}, andor string, prm *[]interface{}) string {
 	return mbq(strings.TrimSpace(strings.Join(whr, andor)))
 }
 
+func MergeMap(m ...map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	for i := 0; i < len(m); i++ {
+		for k, v := range m[i] {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+func EncodeCursor(vals []interface{}) (string, error) {
+	res := make([]interface{}, len(vals))
+
+	for i := 0; i < len(vals); i++ {
+		switch v := vals[i].(type) {
+		case nil:
+			return "", errors.New("cursor value is NULL")
+		case []byte:
+			res[i] = string(v)
+		case time.Time:
+			res[i] = v.Format("2006-01-02 15:04:05.999999")
+		default:
+			res[i] = v
+		}
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals []interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if err = dec.Decode(&vals); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(vals); i++ {
+		switch v := vals[i].(type) {
+		case json.Number:
+			if n, e := v.Int64(); e == nil {
+				vals[i] = n
+
+				continue
+			}
+
+			if vals[i], err = v.Float64(); err != nil {
+				return nil, err
+			}
+		case string:
+		default:
+			return nil, errors.New("invalid cursor value")
+		}
+	}
+
+	return vals, nil
+}
+
+func Quote(s string) string {
+	return "'" + ReplaceAll(s,
+		[2]string{"\\", "\\\\"},
+		[2]string{"'", "\\'"},
+		[2]string{"\x00", "\\0"},
+		[2]string{"\n", "\\n"},
+		[2]string{"\r", "\\r"},
+		[2]string{"\x1a", "\\Z"},
+	) + "'"
+}
+
+func Literal(i interface{}) string {
+	if v, ok := i.(driver.Valuer); ok {
+		val, err := v.Value()
+		if err != nil {
+			return "NULL"
+		}
+
+		i = val
+	}
+
+	switch i.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if i.(bool) {
+			return "1"
+		}
+
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ItoS(i)
+	case float32, float64:
+		return fmt.Sprintf("%v", i)
+	case string:
+		return Quote(i.(string))
+	case []byte:
+		if i.([]byte) == nil {
+			return "NULL"
+		}
+
+		return Quote(string(i.([]byte)))
+	case time.Time:
+		if i.(time.Time).IsZero() {
+			return "'0000-00-00'"
+		}
+
+		return "'" + i.(time.Time).Format("2006-01-02 15:04:05.999999") + "'"
+	default:
+		return Quote(fmt.Sprintf("%v", i))
+	}
+}
+
+func Interpolate(query string, args []interface{}) string {
+	var (
+		n     int
+		quote byte
+		buf   strings.Builder
+	)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' && i+1 < len(query) {
+				buf.WriteByte(c)
+				i++
+				c = query[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && n < len(args):
+			buf.WriteString(Literal(args[n]))
+			n++
+
+			continue
+		}
+
+		buf.WriteByte(c)
+	}
+
+	return buf.String()
+}
+
 func MakeArgs(n int) []interface{} {
 	args := make([]interface{}, n)
 
@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type fileConfig struct {
+	Connections map[string]map[string]interface{} `json:"connections" yaml:"connections" toml:"connections"`
+}
+
+func configKey(k string) string {
+	k = strings.ToLower(strings.Replace(k, "_", "", -1))
+
+	for _, v := range envKeys {
+		if strings.ToLower(v) == k {
+			return v
+		}
+	}
+
+	return k
+}
+
+func LoadConfig(path string) (map[string]*Config, error) {
+	var fc fileConfig
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	case ".json":
+		err = json.Unmarshal(data, &fc)
+	case ".toml":
+		err = toml.Unmarshal(data, &fc)
+	default:
+		return nil, errors.New("unsupported config file " + path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fc.Connections) == 0 {
+		return nil, errors.New("no connections in " + path)
+	}
+
+	res := make(map[string]*Config, len(fc.Connections))
+
+	for name, conn := range fc.Connections {
+		cfg, err := parseConnection(conn)
+		if err != nil {
+			return nil, errors.New(name + ": " + err.Error())
+		}
+
+		res[name] = cfg
+	}
+
+	return res, nil
+}
+
+func parseConnection(conn map[string]interface{}) (cfg *Config, err error) {
+	cfg = &Config{}
+
+	for k, v := range conn {
+		switch configKey(k) {
+		case "url":
+			cfg, err = parseURL(ItoS(v))
+		case "dsn":
+			cfg, err = parseDSN(ItoS(v))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for k, v := range conn {
+		switch key := configKey(k); key {
+		case "url", "dsn":
+		case "passwordfile":
+			pwd, err := ioutil.ReadFile(ItoS(v))
+			if err != nil {
+				return nil, err
+			}
+
+			cfg.Password = strings.TrimRight(string(pwd), "\r\n")
+		case "hosts":
+			if list, ok := v.([]interface{}); ok {
+				for _, h := range list {
+					cfg.Hosts = append(cfg.Hosts, ItoS(h))
+				}
+			} else if err = cfg.set(key, ItoS(v)); err != nil {
+				return nil, err
+			}
+		case "replicas":
+			var list []interface{}
+
+			switch v.(type) {
+			case []interface{}:
+				list = v.([]interface{})
+			case []map[string]interface{}:
+				for _, item := range v.([]map[string]interface{}) {
+					list = append(list, item)
+				}
+			default:
+				return nil, errors.New("replicas must be a list")
+			}
+
+			for _, item := range list {
+				r, err := parseReplica(item)
+				if err != nil {
+					return nil, err
+				}
+
+				cfg.Replicas = append(cfg.Replicas, r)
+			}
+		case "params":
+			params, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("params must be a map")
+			}
+
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]string, len(params))
+			}
+
+			for pk, pv := range params {
+				cfg.Params[pk] = ItoS(pv)
+			}
+		default:
+			if err = cfg.set(key, ItoS(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func parseReplica(item interface{}) (Replica, error) {
+	var r Replica
+
+	switch item.(type) {
+	case string:
+		host, port, err := net.SplitHostPort(item.(string))
+		if err != nil {
+			return r, err
+		}
+
+		r.Host, r.Port = host, port
+	case map[string]interface{}:
+		for k, v := range item.(map[string]interface{}) {
+			switch strings.ToLower(k) {
+			case "host":
+				r.Host = ItoS(v)
+			case "port":
+				r.Port = ItoS(v)
+			case "weight":
+				w, err := strconv.Atoi(ItoS(v))
+				if err != nil {
+					return r, errors.New("invalid replica weight " + ItoS(v))
+				}
+
+				r.Weight = w
+			}
+		}
+	default:
+		return r, errors.New("invalid replica")
+	}
+
+	if r.Host == "" {
+		return r, errors.New("replica host is required")
+	}
+
+	return r, nil
+}
@@ -0,0 +1,134 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qkofy/log"
+)
+
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+type SugaredLogger interface {
+	Debugw(msg string, kv ...interface{})
+	Infow(msg string, kv ...interface{})
+	Warnw(msg string, kv ...interface{})
+	Errorw(msg string, kv ...interface{})
+}
+
+type stdLogger struct {
+	lgr *log.Logger
+}
+
+type sugaredLogger struct {
+	lgr SugaredLogger
+}
+
+type nopLogger struct{}
+
+var logger Logger = stdLogger{log.New(&log.Config{})}
+
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	logger = l
+}
+
+func NewStdLogger(lgr *log.Logger) Logger {
+	return stdLogger{lgr}
+}
+
+func NewSugaredLogger(lgr SugaredLogger) Logger {
+	return sugaredLogger{lgr}
+}
+
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+func FormatFields(msg string, kv ...interface{}) string {
+	res := []string{msg}
+
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			res = append(res, fmt.Sprintf("%v=%v", kv[i], kv[i+1]))
+		} else {
+			res = append(res, fmt.Sprintf("%v", kv[i]))
+		}
+	}
+
+	return strings.Join(res, " ")
+}
+
+func (l stdLogger) Debug(msg string, kv ...interface{}) {
+	l.lgr.Debug(FormatFields(msg, kv...))
+}
+
+func (l stdLogger) Info(msg string, kv ...interface{}) {
+	l.lgr.Info(FormatFields(msg, kv...))
+}
+
+func (l stdLogger) Warn(msg string, kv ...interface{}) {
+	l.lgr.Warning(FormatFields(msg, kv...))
+}
+
+func (l stdLogger) Error(msg string, kv ...interface{}) {
+	l.lgr.Error(FormatFields(msg, kv...))
+}
+
+func (l sugaredLogger) Debug(msg string, kv ...interface{}) {
+	l.lgr.Debugw(msg, kv...)
+}
+
+func (l sugaredLogger) Info(msg string, kv ...interface{}) {
+	l.lgr.Infow(msg, kv...)
+}
+
+func (l sugaredLogger) Warn(msg string, kv ...interface{}) {
+	l.lgr.Warnw(msg, kv...)
+}
+
+func (l sugaredLogger) Error(msg string, kv ...interface{}) {
+	l.lgr.Errorw(msg, kv...)
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func (nopLogger) Info(string, ...interface{}) {}
+
+func (nopLogger) Warn(string, ...interface{}) {}
+
+func (nopLogger) Error(string, ...interface{}) {}
+
+func fatal(i ...interface{}) {
+	logger.Error(strings.TrimSpace(fmt.Sprintln(i...)))
+	os.Exit(1)
+}
+
+func (db *DB) logger() Logger {
+	if db.log != nil {
+		return db.log
+	}
+
+	return logger
+}
+
+func (db *DB) SetLogger(l Logger) *DB {
+	db.log = l
+
+	if db.replicas != nil {
+		db.replicas.Lock()
+		db.replicas.log = l
+		db.replicas.Unlock()
+	}
+
+	return db
+}
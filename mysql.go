@@ -1,59 +1,105 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
-
-	_ "github.com/go-sql-driver/mysql"
+	"time"
 )
 
-type DB struct {
-	SQL    *sql.DB
-	stmt   *sql.Stmt
-	rows   *sql.Rows
+var primaryKeys sync.Map
+
+type pkKey struct {
 	config *Config
-	params sync.Pool
-	pk     string
 	table  string
-	alias  string
+}
+
+type state struct {
 	field  string
+	alias  string
 	force  string
 	where  string
 	order  string
 	limit  string
-	LastId int64
-	RowNum int64
+	lock   string
+	master bool
+	params []interface{}
+}
+
+type Page struct {
+	Items   []interface{}
+	Total   int
+	Page    int
+	PerPage int
+	Pages   int
+	HasNext bool
+	HasPrev bool
+}
+
+type CursorPage struct {
+	Items   []interface{}
+	Next    string
+	HasNext bool
+}
+
+type failure struct {
+	err error
+}
+
+type DB struct {
+	SQL       *sql.DB
+	stmt      *sql.Stmt
+	rows      *sql.Rows
+	config    *Config
+	name      string
+	replicas  *replicaSet
+	primaries *primarySet
+	params    []interface{}
+	pk        string
+	table     string
+	alias     string
+	field     string
+	force     string
+	where     string
+	order     string
+	limit     string
+	lock      string
+	cursor    string
+	ctx       context.Context
+	tx        *sql.Tx
+	catch     bool
+	log       Logger
+	last      string
+	args      []interface{}
+	began     time.Time
+	master    bool
+	dry       bool
+	recorder  *recorder
+	LastId    int64
+	RowNum    int64
 }
 
 func Open(cfg *Config) *DB {
 	cfg = cfg.Configure()
 
-	opt := []string{
-		cfg.Username,
-		":",
-		cfg.Password,
-		"@tcp(",
-		cfg.Host,
-		":",
-		cfg.Port,
-		")/",
-	}
+	pool, primaries := openPrimary(cfg)
 
-	if cfg.useDb {
-		opt = []string{
-			strings.Join(opt, ""),
-			cfg.Database,
-			"?charset=",
-			cfg.Charset,
-		}
-	}
+	return &DB{SQL: pool, config: cfg, primaries: primaries, replicas: openReplicas(cfg), recorder: &recorder{}, field: "*"}
+}
 
-	dsn := strings.Join(opt, "")
+func openPool(cfg *Config) *sql.DB {
+	dsn, err := cfg.FormatDSN()
+	if err != nil {
+		fatal(err)
+	}
 
 	if cfg.Debug {
-		logger.Debug(dsn)
+		logger.Debug("open", "dsn", RedactDSN(dsn))
 	}
 
 	db, err := sql.Open("mysql", dsn)
@@ -63,10 +109,26 @@ func Open(cfg *Config) *DB {
 			_ = db.Close()
 		}()
 
-		logger.Fatal(err)
+		fatal(err)
+	}
+
+	if cfg.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
 	}
 
-	return &DB{SQL: db, config: cfg, field: "*"}
+	if cfg.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	return db
 }
 
 func New(cfg *Config) *DB {
@@ -75,18 +137,201 @@ func New(cfg *Config) *DB {
 	return Open(cfg)
 }
 
-func (db *DB) setParams(i []interface{}) {
-	if i != nil {
-		db.params.Put(i)
+func (db *DB) clone() *DB {
+	return &DB{
+		SQL:       db.SQL,
+		config:    db.config,
+		replicas:  db.replicas,
+		primaries: db.primaries,
+		pk:        db.pk,
+		table:     db.table,
+		field:     "*",
+		ctx:       db.ctx,
+		tx:        db.tx,
+		log:       db.log,
+		catch:     db.catch,
+		dry:       db.dry,
+		recorder:  db.recorder,
+	}
+}
+
+func (db *DB) fork() *DB {
+	q := db.clone().restore(db.snapshot())
+	q.cursor = db.cursor
+
+	db.restore(state{field: "*"})
+	db.cursor = ""
+
+	return q
+}
+
+func (db *DB) fatal(i ...interface{}) {
+	if err, ok := i[len(i)-1].(error); ok && len(i) == 1 {
+		err = db.wrap(err)
+
+		if isConnError(err) || IsReadOnly(err) {
+			db.failover()
+		}
+
+		if !db.catch {
+			db.logger().Error("query failed", "error", err)
+			os.Exit(1)
+		}
+
+		panic(failure{err})
+	}
+
+	if !db.catch {
+		db.logger().Error(strings.TrimSpace(fmt.Sprintln(i...)))
+		os.Exit(1)
 	}
+
+	panic(failure{errors.New(strings.TrimSpace(fmt.Sprintln(i...)))})
+}
+
+func catchFailure(err *error) {
+	if r := recover(); r != nil {
+		f, ok := r.(failure)
+		if !ok {
+			panic(r)
+		}
+
+		*err = f.err
+	}
+}
+
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+
+	return db.ctx
+}
+
+func (db *DB) WithContext(ctx context.Context) *DB {
+	q := db.fork()
+	q.ctx = ctx
+
+	return q
+}
+
+func (db *DB) Transaction(fn func(tx *DB) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	if db.dryRun() {
+		return db.dryTransaction(fn)
+	}
+
+	return db.config.Retry.do(db.context(), db.logger(), func() error {
+		return db.transaction(fn)
+	})
+}
+
+func (db *DB) transaction(fn func(tx *DB) error) (err error) {
+	db.syncPrimary()
+
+	sqlTx, err := db.SQL.BeginTx(db.context(), nil)
+	if err != nil {
+		if isConnError(err) {
+			db.failover()
+		}
+
+		return err
+	}
+
+	tx := db.clone()
+	tx.tx = sqlTx
+	tx.catch = true
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = sqlTx.Rollback()
+
+			f, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+
+			err = f.err
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		_ = sqlTx.Rollback()
+
+		return
+	}
+
+	return sqlTx.Commit()
+}
+
+func (db *DB) dryTransaction(fn func(tx *DB) error) (err error) {
+	tx := db.clone()
+	tx.catch = true
+
+	tx.record("BEGIN", nil)
+
+	defer func() {
+		if r := recover(); r != nil {
+			f, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+
+			err = f.err
+		}
+
+		if err != nil {
+			tx.record("ROLLBACK", nil)
+		} else {
+			tx.record("COMMIT", nil)
+		}
+	}()
+
+	return fn(tx)
+}
+
+func (db *DB) setParams(i []interface{}) {
+	db.params = i
 }
 
 func (db *DB) getParams() []interface{} {
-	ps, _ := db.params.Get().([]interface{})
+	ps := db.params
+	db.params = nil
 
 	return ps
 }
 
+func (db *DB) snapshot() state {
+	return state{
+		field:  db.field,
+		alias:  db.alias,
+		force:  db.force,
+		where:  db.where,
+		order:  db.order,
+		limit:  db.limit,
+		lock:   db.lock,
+		master: db.master,
+		params: db.params,
+	}
+}
+
+func (db *DB) restore(st state) *DB {
+	db.field  = st.field
+	db.alias  = st.alias
+	db.force  = st.force
+	db.where  = st.where
+	db.order  = st.order
+	db.limit  = st.limit
+	db.lock   = st.lock
+	db.master = st.master
+	db.params = st.params
+
+	return db
+}
+
 func (db *DB) Configure(k string, v interface{}) *DB {
 	switch k {
 	case "Prefix":
@@ -95,13 +340,84 @@ func (db *DB) Configure(k string, v interface{}) *DB {
 		db.config.Debug = v.(bool)
 	case "Explain":
 		db.config.Explain = v.(bool)
+	case "DryRun":
+		db.config.DryRun = v.(bool)
 	default:
-		logger.Error(k + " is invalid argument")
+		db.logger().Error(k + " is invalid argument")
 	}
 
 	return db
 }
 
+func (db *DB) lookupPK() string {
+	if db.pk != "" {
+		return db.pk
+	}
+
+	key   := pkKey{db.config, db.table}
+	cache := db.config.useDb || strings.Contains(db.table, ".")
+
+	if !strings.Contains(key.table, ".") {
+		key.table = db.config.Database + "." + key.table
+	}
+
+	if pk, ok := primaryKeys.Load(key); ok && cache {
+		db.pk = pk.(string)
+
+		return db.pk
+	}
+
+	if db.dryRun() {
+		db.pk = "id"
+
+		return db.pk
+	}
+
+	schema, table := "DATABASE()", db.table
+	args := []interface{}{table}
+
+	if i := strings.Index(table, "."); i >= 0 {
+		schema = "?"
+		args   = []interface{}{table[:i], table[i+1:]}
+	}
+
+	res := db.clone().Query(strings.Join([]string{
+		"SELECT `COLUMN_NAME` FROM `information_schema`.`KEY_COLUMN_USAGE`",
+		" WHERE `TABLE_SCHEMA` = ",
+		schema,
+		" AND `TABLE_NAME` = ? AND `CONSTRAINT_NAME` = 'PRIMARY'",
+		" ORDER BY `ORDINAL_POSITION`",
+	}, ""), args...)
+
+	var cols []string
+
+	for i := 0; i < len(res); i++ {
+		cols = append(cols, ItoS(res[i].(map[string]interface{})["COLUMN_NAME"]))
+	}
+
+	db.pk = strings.Join(cols, ",")
+
+	if cache {
+		primaryKeys.Store(key, db.pk)
+	}
+
+	return db.pk
+}
+
+func (db *DB) primaryKey() string {
+	pk := db.lookupPK()
+
+	if pk == "" {
+		db.fatal("table " + db.table + " has no primary key")
+	}
+
+	if strings.Contains(pk, ",") {
+		db.fatal("table " + db.table + " has a composite primary key")
+	}
+
+	return pk
+}
+
 func (db *DB) Table(name string) *DB {
 	if strings.HasPrefix(name, db.config.Prefix) {
 		db.table = name
@@ -109,6 +425,8 @@ func (db *DB) Table(name string) *DB {
 		db.table = db.config.Prefix + name
 	}
 
+	db.pk = ""
+
 	return db
 }
 
@@ -162,7 +480,7 @@ func (db *DB) Where(w interface{}, andor ...string) *DB {
 	case 1:
 		dr = " " + andor[0] + " "
 	default:
-		logger.Fatal("too many arguments")
+		db.fatal("too many arguments")
 	}
 
 	switch w.(type) {
@@ -182,13 +500,6 @@ func (db *DB) Where(w interface{}, andor ...string) *DB {
 		db.where = strings.Join(whr, dr)
 	}
 
-	if db.params.New == nil && len(prm) > 0 {
-		db.params.New = func() interface{} {
-			ps := make([]interface{}, len(prm))
-			return &ps
-		}
-	}
-
 	db.setParams(prm)
 
 	return db
@@ -211,7 +522,7 @@ func (db *DB) Order(o interface{}) *DB {
 	case []string:
 		by(db, o.([]string))
 	default:
-		logger.Fatal("arguments error")
+		db.fatal("arguments error")
 	}
 
 	return db
@@ -228,7 +539,7 @@ func (db *DB) Limit(l ...interface{}) *DB {
 
 		for i := 0; i < len(l); i++ {
 			if l[i] == "" {
-				logger.Fatal("arguments error")
+				db.fatal("arguments error")
 			}
 
 			limit = append(limit, fmt.Sprintf("%v", l[i]))
@@ -236,14 +547,56 @@ func (db *DB) Limit(l ...interface{}) *DB {
 
 		db.limit = strings.Join(limit, ", ")
 	default:
-		logger.Fatal("too many arguments")
+		db.fatal("too many arguments")
 	}
 
 	return db
 }
 
-func (db *DB) MakeSQL() string {
-	var query, force, where, order, limit string
+func (db *DB) setLock(lock string) *DB {
+	if db.tx == nil && !db.dryRun() {
+		db.fatal("locking reads require a transaction")
+	}
+
+	db.lock = lock
+
+	return db
+}
+
+func (db *DB) LockForUpdate() *DB {
+	return db.setLock(" FOR UPDATE")
+}
+
+func (db *DB) LockInShareMode() *DB {
+	return db.setLock(" LOCK IN SHARE MODE")
+}
+
+func (db *DB) ForShare() *DB {
+	return db.setLock(" FOR SHARE")
+}
+
+func (db *DB) NoWait() *DB {
+	if db.lock != " FOR UPDATE" && db.lock != " FOR SHARE" {
+		db.fatal("NOWAIT requires FOR UPDATE or FOR SHARE")
+	}
+
+	db.lock += " NOWAIT"
+
+	return db
+}
+
+func (db *DB) SkipLocked() *DB {
+	if db.lock != " FOR UPDATE" && db.lock != " FOR SHARE" {
+		db.fatal("SKIP LOCKED requires FOR UPDATE or FOR SHARE")
+	}
+
+	db.lock += " SKIP LOCKED"
+
+	return db
+}
+
+func (db *DB) buildSQL() string {
+	var force, where, order, limit string
 
 	table := "`" + db.table + "`"
 
@@ -253,68 +606,107 @@ func (db *DB) MakeSQL() string {
 
 	if db.force != "" {
 		force = " FORCE INDEX(" + db.force + ")"
-		db.force = ""
 	}
 
 	if db.where != "" {
 		where = " WHERE " + db.where
-		db.where = ""
 	}
 
 	if db.order != "" {
 		order = " ORDER BY " + db.order
-		db.order = ""
 	}
 
 	if db.limit != "" {
 		limit = " LIMIT " + db.limit
-		db.limit = ""
 	}
 
-	field := db.field
-	db.field = "*"
-
-	query = strings.Join([]string{
+	return strings.Join([]string{
 		"SELECT ",
-		field,
+		db.field,
 		" FROM ",
 		table,
 		force,
 		where,
 		order,
 		limit,
+		db.lock,
 	}, "")
+}
+
+func (db *DB) ToSQL() (string, []interface{}) {
+	args := make([]interface{}, len(db.params))
+	copy(args, db.params)
+
+	return db.buildSQL(), args
+}
+
+func (db *DB) ToRawSQL() string {
+	return Interpolate(db.ToSQL())
+}
+
+func (db *DB) MakeSQL() string {
+	query := db.buildSQL()
+
+	db.force = ""
+	db.where = ""
+	db.order = ""
+	db.limit = ""
+	db.lock  = ""
+	db.field = "*"
 
 	if db.config.Debug {
-		logger.Debug(query)
+		db.logger().Debug("build", "sql", query, "args", db.maskArgs(query, db.params))
 	}
 
-	if db.config.Explain {
-		args := db.getParams()
-		res  := db.Query("EXPLAIN " + query, args...)
-
-		for i := 0; i < len(res); i++ {
-			logger.Debug(ItoS(res[i]))
+	if db.config.Explain && !db.dryRun() {
+		for _, v := range db.explain(query, db.params) {
+			db.logger().Debug("explain", "sql", query, "plan", v)
 		}
-
-		db.setParams(args)
 	}
 
 	return query
 }
 
 func (db *DB) prepare(query string) *sql.Stmt {
-	if db.config.Debug {
-		logger.Debug(query)
+	db.syncPrimary()
+
+	return db.prepareOn(db.SQL, query)
+}
+
+func (db *DB) prepareRead(query string) *sql.Stmt {
+	db.syncPrimary()
+
+	conn := db.SQL
+
+	if !db.master && db.tx == nil {
+		conn = db.replicas.next(db.SQL)
 	}
 
-	stmt, err := db.SQL.Prepare(query)
-	if err != nil {
-		defer func() {
-			_ = stmt.Close()
-		}()
+	db.master = false
+
+	return db.prepareOn(conn, query)
+}
+
+func (db *DB) prepareOn(conn *sql.DB, query string) *sql.Stmt {
+	db.last = query
 
-		logger.Fatal(err)
+	var (
+		stmt *sql.Stmt
+		err  error
+	)
+
+	if db.tx != nil {
+		stmt, err = db.tx.PrepareContext(db.context(), query)
+	} else {
+		stmt, err = conn.PrepareContext(db.context(), query)
+
+		if err != nil && conn == db.SQL && isConnError(err) && db.failover() {
+			stmt, err = db.SQL.PrepareContext(db.context(), query)
+		}
+	}
+
+	if err != nil {
+		db.fatal(err)
 	}
 
 	return stmt
@@ -326,45 +718,63 @@ func (db *DB) Prepare(query string) *sql.Stmt {
 	return db.stmt
 }
 
-func (db *DB) sqlStmt() *sql.Stmt {
-	return db.prepare(db.MakeSQL())
-}
-
 func (db *DB) stmtClose() {
 	_ = db.stmt.Close()
 }
 
 func (db *DB) rowsClose() {
-	_ = db.rows.Close()
+	if db.rows != nil {
+		_ = db.rows.Close()
+	}
 }
 
 func (db *DB) fetch(args ...interface{}) (fields []string) {
-	rows, err := db.stmt.Query(args...)
+	db.began = time.Now()
+	db.args  = args
+
+	rows, err := db.stmt.QueryContext(db.context(), args...)
 	defer db.stmtClose()
 
 	db.rows = rows
 
 	if err != nil {
-		defer db.rowsClose()
-		logger.Fatal(err)
+		db.trace(db.began, db.last, args, 0, err)
+		db.fatal(err)
 	}
 
 	fields, err = rows.Columns()
 	if err != nil {
 		defer db.rowsClose()
-		logger.Fatal(err)
+		db.fatal(err)
 	}
 
 	return
 }
 
 func (db *DB) Fetch() (fields []string) {
-	db.stmt = db.sqlStmt()
+	query := db.MakeSQL()
+	args  := db.getParams()
 
-	return db.fetch(db.getParams()...)
+	if db.dryRun() {
+		db.record(query, args)
+		db.rows = nil
+
+		return
+	}
+
+	db.retryRead(func() {
+		db.stmt = db.prepareRead(query)
+		fields  = db.fetch(args...)
+	})
+
+	return
 }
 
 func (db *DB) Result(fields []string) (res []interface{}) {
+	if db.rows == nil {
+		return
+	}
+
 	defer db.rowsClose()
 
 	for db.rows.Next() {
@@ -372,7 +782,7 @@ func (db *DB) Result(fields []string) (res []interface{}) {
 
 		err := db.rows.Scan(data...)
 		if err != nil {
-			logger.Error(err)
+			db.trace(db.began, db.last, db.args, int64(len(res)), err)
 
 			return
 		}
@@ -385,6 +795,8 @@ func (db *DB) Result(fields []string) (res []interface{}) {
 		res = append(res, ret)
 	}
 
+	db.trace(db.began, db.last, db.args, int64(len(res)), db.rows.Err())
+
 	return
 }
 
@@ -392,103 +804,512 @@ func (db *DB) Select() []interface{} {
 	return db.Result(db.Fetch())
 }
 
-func (db *DB) Find() map[string]interface{} {
+func (db *DB) Find() (map[string]interface{}, error) {
 	db.limit = "1"
 
 	fields := db.Fetch()
 
 	db.limit = ""
 
-	return db.Result(fields)[0].(map[string]interface{})
+	res := db.Result(fields)
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return res[0].(map[string]interface{}), nil
+}
+
+func (db *DB) Pluck(field string) (res []interface{}) {
+	name := FieldName(field)
+	rows := db.Field(field).Select()
+
+	for i := 0; i < len(rows); i++ {
+		res = append(res, rows[i].(map[string]interface{})[name])
+	}
+
+	return
+}
+
+func (db *DB) Column(field, key string) map[string]interface{} {
+	if key == "" {
+		key = db.primaryKey()
+	}
+
+	name  := FieldName(field)
+	kname := FieldName(key)
+	rows  := db.Field([]string{field, key}).Select()
+	res   := make(map[string]interface{}, len(rows))
+
+	for i := 0; i < len(rows); i++ {
+		row := rows[i].(map[string]interface{})
+		res[ItoS(row[kname])] = row[name]
+	}
+
+	return res
+}
+
+func (db *DB) KeyBy(key string) map[string]map[string]interface{} {
+	if key == "" {
+		key = db.primaryKey()
+	}
+
+	kname := FieldName(key)
+	rows  := db.Select()
+	res   := make(map[string]map[string]interface{}, len(rows))
+
+	for i := 0; i < len(rows); i++ {
+		row := rows[i].(map[string]interface{})
+		res[ItoS(row[kname])] = row
+	}
+
+	return res
+}
+
+func (db *DB) FindByPK(id interface{}) (map[string]interface{}, error) {
+	return db.Where([]interface{}{[]interface{}{db.primaryKey(), id}}).Find()
+}
+
+func (db *DB) FindManyByPK(ids ...interface{}) []interface{} {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	val := make([]string, len(ids))
+	for i := 0; i < len(ids); i++ {
+		val[i] = "?"
+	}
+
+	_ = db.getParams()
+	db.setParams(ids)
+
+	db.where = MakeBackQuote(db.primaryKey(), " ") + " IN (" + strings.Join(val, ", ") + ")"
+
+	return db.Select()
+}
+
+func (db *DB) whereMap(data map[string]interface{}) *DB {
+	var whr []interface{}
+
+	for k, v := range data {
+		whr = append(whr, []interface{}{k, v})
+	}
+
+	return db.Where(whr)
+}
+
+func (db *DB) FirstOrCreate(attributes, defaults map[string]interface{}) (row map[string]interface{}, err error) {
+	err = db.Transaction(func(tx *DB) error {
+		var e error
+
+		if row, e = tx.LockForUpdate().whereMap(attributes).Find(); e != ErrNotFound {
+			return e
+		}
+
+		tx.Insert(MergeMap(defaults, attributes))
+
+		row, e = tx.whereMap(attributes).Find()
+
+		return e
+	})
+
+	return
+}
+
+func (db *DB) UpdateOrCreate(attributes, values map[string]interface{}) (row map[string]interface{}, err error) {
+	err = db.Transaction(func(tx *DB) error {
+		var e error
+
+		_, e = tx.LockForUpdate().whereMap(attributes).Find()
+
+		switch e {
+		case nil:
+			tx.whereMap(attributes).Update(values)
+		case ErrNotFound:
+			tx.Insert(MergeMap(values, attributes))
+		default:
+			return e
+		}
+
+		row, e = tx.whereMap(attributes).Find()
+
+		return e
+	})
+
+	return
+}
+
+func (db *DB) Paginate(page, perPage int) *Page {
+	if perPage < 1 {
+		db.fatal("arguments error")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	st := db.snapshot()
+
+	db.order = ""
+	db.limit = ""
+	db.lock  = ""
+
+	res := &Page{Page: page, PerPage: perPage, Total: db.Count()}
+
+	res.Pages   = (res.Total + perPage - 1) / perPage
+	res.HasNext = page < res.Pages
+	res.HasPrev = page > 1
+
+	db.restore(st)
+
+	if res.Total > (page-1)*perPage || db.dryRun() {
+		res.Items = db.Limit((page-1)*perPage, perPage).Select()
+	} else {
+		db.restore(state{field: "*"})
+	}
+
+	return res
+}
+
+func (db *DB) SeekAfter(cursor string) *DB {
+	db.cursor = cursor
+
+	return db
+}
+
+func (db *DB) CursorPaginate(limit int) (*CursorPage, error) {
+	if limit < 1 {
+		db.fatal("arguments error")
+	}
+
+	if db.order == "" {
+		db.Order(db.primaryKey())
+	}
+
+	cursor := db.cursor
+	db.cursor = ""
+
+	var (
+		cols []string
+		dirs []string
+	)
+
+	for _, v := range strings.Split(db.order, ",") {
+		f := strings.Fields(v)
+
+		dir := "asc"
+		if len(f) > 1 {
+			dir = strings.ToLower(f[1])
+		}
+
+		if len(dirs) > 0 && dirs[0] != dir {
+			db.restore(state{field: "*"})
+
+			return nil, errors.New("cursor pagination requires a single order direction")
+		}
+
+		cols = append(cols, f[0])
+		dirs = append(dirs, dir)
+	}
+
+	if cursor != "" {
+		vals, err := DecodeCursor(cursor)
+		if err != nil || len(vals) != len(cols) {
+			db.restore(state{field: "*"})
+
+			return nil, errors.New("invalid cursor")
+		}
+
+		op := " > "
+		if dirs[0] == "desc" {
+			op = " < "
+		}
+
+		val := make([]string, len(vals))
+		for i := 0; i < len(vals); i++ {
+			val[i] = "?"
+		}
+
+		cond := "(" + strings.Join(cols, ", ") + ")" + op + "(" + strings.Join(val, ", ") + ")"
+
+		if db.where == "" {
+			db.where = cond
+		} else {
+			db.where = "(" + db.where + ") and " + cond
+		}
+
+		db.setParams(append(db.getParams(), vals...))
+	}
+
+	db.limit = fmt.Sprintf("%d", limit+1)
+
+	res := &CursorPage{Items: db.Select()}
+
+	if len(res.Items) > limit {
+		res.Items   = res.Items[:limit]
+		res.HasNext = true
+
+		last := res.Items[limit-1].(map[string]interface{})
+		vals := make([]interface{}, len(cols))
+
+		for i := 0; i < len(cols); i++ {
+			v, ok := last[FieldName(cols[i])]
+			if !ok {
+				return nil, errors.New("cursor column " + cols[i] + " is not selected")
+			}
+
+			vals[i] = v
+		}
+
+		next, err := EncodeCursor(vals)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Next = next
+	}
+
+	return res, nil
+}
+
+func (db *DB) Chunk(size int, fn func(rows []interface{}) error) error {
+	return db.ChunkByID(size, db.primaryKey(), fn)
+}
+
+func (db *DB) ChunkByID(size int, column string, fn func(rows []interface{}) error) error {
+	if size < 1 {
+		db.fatal("arguments error")
+	}
+
+	var last interface{}
+
+	st   := db.snapshot()
+	col  := MakeBackQuote(column, " ")
+	name := FieldName(column)
+
+	q := db.clone()
+	db.restore(state{field: "*"})
+
+	for {
+		q.restore(st)
+
+		q.order = col + " asc"
+		q.limit = fmt.Sprintf("%d", size)
+
+		if last != nil {
+			if q.where == "" {
+				q.where = col + " > ?"
+			} else {
+				q.where = "(" + q.where + ") and " + col + " > ?"
+			}
+
+			q.params = append(append([]interface{}{}, st.params...), last)
+		}
+
+		rows := q.Select()
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if err := fn(rows); err != nil {
+			return err
+		}
+
+		if len(rows) < size {
+			return nil
+		}
+
+		var ok bool
+
+		if last, ok = rows[len(rows)-1].(map[string]interface{})[name]; !ok {
+			return errors.New("chunk column " + column + " is not selected")
+		}
+
+		if last == nil {
+			return errors.New("chunk column " + column + " is NULL")
+		}
+	}
 }
 
 func (db *DB) Value(field string) string {
 	db.field = field
-	db.stmt  = db.sqlStmt()
-	defer db.stmtClose()
 
 	var res interface{}
 
-	err := db.stmt.QueryRow(db.getParams()...).Scan(&res)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return "<nil>"
-		}
+	query := db.MakeSQL()
+	args  := db.getParams()
 
-		logger.Fatal(err)
+	if db.dryRun() {
+		db.record(query, args)
+
+		return "<nil>"
 	}
 
+	db.retryRead(func() {
+		db.stmt = db.prepareRead(query)
+		defer db.stmtClose()
+
+		start := time.Now()
+
+		err := db.stmt.QueryRowContext(db.context(), args...).Scan(&res)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				db.trace(start, query, args, 0, nil)
+				res = "<nil>"
+
+				return
+			}
+
+			db.trace(start, query, args, 0, err)
+			db.fatal(err)
+		}
+
+		db.trace(start, query, args, 1, nil)
+	})
+
 	return ItoS(res)
 }
 
 func (db *DB) Count() (num int) {
 	db.field = "count(1)"
-	db.stmt  = db.sqlStmt()
-	defer db.stmtClose()
 
-	err := db.stmt.QueryRow(db.getParams()...).Scan(&num)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return -1
-		}
+	query := db.MakeSQL()
+	args  := db.getParams()
 
-		logger.Fatal(err)
+	if db.dryRun() {
+		db.record(query, args)
+
+		return
 	}
 
+	db.retryRead(func() {
+		db.stmt = db.prepareRead(query)
+		defer db.stmtClose()
+
+		start := time.Now()
+
+		err := db.stmt.QueryRowContext(db.context(), args...).Scan(&num)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				db.trace(start, query, args, 0, nil)
+				num = -1
+
+				return
+			}
+
+			db.trace(start, query, args, 0, err)
+			db.fatal(err)
+		}
+
+		db.trace(start, query, args, 1, nil)
+	})
+
 	return
 }
 
 func (db *DB) Query(query string, args ...interface{}) []interface{} {
-	db.stmt = db.prepare(query)
+	var fields []string
+
+	if db.dryRun() {
+		db.record(query, args)
+		db.rows = nil
+
+		return nil
+	}
+
+	db.retryRead(func() {
+		db.stmt = db.prepareRead(query)
+		fields  = db.fetch(args...)
+	})
 
-	return db.Result(db.fetch(args...))
+	return db.Result(fields)
 }
 
-func (db *DB) OneRow(query string, args ...interface{}) map[string]interface{} {
-	return db.Query(query, args...)[0].(map[string]interface{})
+func (db *DB) OneRow(query string, args ...interface{}) (map[string]interface{}, error) {
+	res := db.Query(query, args...)
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return res[0].(map[string]interface{}), nil
 }
 
 func (db *DB) Exec(query string, args ...interface{}) {
+	if db.dryRun() {
+		db.record(query, args)
+		db.LastId, db.RowNum = 0, 0
+
+		return
+	}
+
 	db.stmt = db.prepare(query)
 	defer db.stmtClose()
 
-	res, err := db.stmt.Exec(args...)
+	start := time.Now()
+
+	res, err := db.stmt.ExecContext(db.context(), args...)
+	if err != nil && (IsReadOnly(err) || errors.Is(err, sqldriver.ErrBadConn)) && db.failover() {
+		db.stmtClose()
+		db.stmt = db.prepare(query)
+
+		start = time.Now()
+		res, err = db.stmt.ExecContext(db.context(), args...)
+	}
+
 	if err != nil {
-		logger.Fatal(err)
+		db.trace(start, query, args, 0, err)
+		db.fatal(err)
 	}
 
 	db.LastId, _ = res.LastInsertId()
 	db.RowNum, _ = res.RowsAffected()
+
+	db.trace(start, query, args, db.RowNum, nil)
 }
 
 func (db *DB) TxExec(query string, args ...interface{}) {
-	tx, err := db.SQL.Begin()
+	if db.tx != nil || db.dryRun() {
+		db.Exec(query, args...)
+
+		return
+	}
+
+	db.last = query
+
+	db.syncPrimary()
+
+	tx, err := db.SQL.BeginTx(db.context(), nil)
+	if err != nil {
+		db.fatal(err)
+	}
+
 	defer func() {
 		_ = tx.Rollback()
 	}()
 
+	db.stmt, err = tx.PrepareContext(db.context(), query)
 	if err != nil {
-		logger.Fatal(err)
+		db.fatal(err)
 	}
 
-	db.stmt, err = tx.Prepare(query)
 	defer db.stmtClose()
 
-	if err != nil {
-		logger.Fatal(err)
-	}
+	start := time.Now()
 
-	res, err := db.stmt.Exec(args...)
+	res, err := db.stmt.ExecContext(db.context(), args...)
 	if err != nil {
-		logger.Fatal(err)
+		db.trace(start, query, args, 0, err)
+		db.fatal(err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		logger.Fatal(err)
+		db.trace(start, query, args, 0, err)
+		db.fatal(err)
 	}
 
 	db.LastId, _ = res.LastInsertId()
 	db.RowNum, _ = res.RowsAffected()
+
+	db.trace(start, query, args, db.RowNum, nil)
 }
 
 func (db *DB) insert(data map[string]interface{}) string {
@@ -519,7 +1340,7 @@ func (db *DB) insert(data map[string]interface{}) string {
 	}, "")
 
 	if db.config.Debug {
-		logger.Debug(query)
+		db.logger().Debug("build", "sql", query, "args", db.maskArgs(query, db.params))
 	}
 
 	return query
@@ -562,7 +1383,7 @@ func (db *DB) insertGroup(data []map[string]interface{}) (string, [][]interface{
 	}, "")
 
 	if db.config.Debug {
-		logger.Debug(query)
+		db.logger().Debug("build", "sql", query, "rows", len(args))
 	}
 
 	return query, args
@@ -598,7 +1419,53 @@ func (db *DB) update(data map[string]interface{}) string {
 	}, "")
 
 	if db.config.Debug {
-		logger.Debug(query)
+		db.logger().Debug("build", "sql", query, "args", db.maskArgs(query, db.params))
+	}
+
+	return query
+}
+
+func (db *DB) increment(column, sign string, amount interface{}, data ...map[string]interface{}) string {
+	var (
+		query string
+		key   []string
+		val   []interface{}
+	)
+
+	if len(data) > 1 {
+		db.fatal("too many arguments")
+	}
+
+	col := MakeBackQuote(column, " ")
+	key  = append(key, col + " = " + col + " " + sign + " ?")
+	val  = append(val, amount)
+
+	if len(data) == 1 {
+		for k, v := range data[0] {
+			key = append(key, "`" + k + "` = ?")
+			val = append(val, v)
+		}
+	}
+
+	val = append(val, db.getParams()...)
+	db.setParams(val)
+
+	query = strings.Join([]string{
+		"UPDATE `",
+		db.table,
+		"` SET ",
+		strings.Join(key, ", "),
+		func() string {
+			if db.where == "" {
+				return ""
+			} else {
+				return " WHERE " + db.where
+			}
+		}(),
+	}, "")
+
+	if db.config.Debug {
+		db.logger().Debug("build", "sql", query, "args", db.maskArgs(query, db.params))
 	}
 
 	return query
@@ -652,15 +1519,28 @@ func (db *DB) updateGroup(data []map[string]interface{}) (string, [][]interface{
 	return query, args
 }
 
+func (db *DB) fillPK(data map[string]interface{}, id int64) {
+	pk := db.lookupPK()
+
+	if pk == "" || strings.Contains(pk, ",") || id <= 0 {
+		return
+	}
+
+	if _, ok := data[pk]; !ok {
+		data[pk] = id
+	}
+}
+
 func (db *DB) save(data interface{}, handle string) {
 	switch data.(type) {
 	case map[string]interface{}:
 		if handle == "insert" {
 			db.Exec(db.insert(data.(map[string]interface{})), db.getParams()...)
+			db.fillPK(data.(map[string]interface{}), db.LastId)
 		} else if handle == "update" {
 			db.Exec(db.update(data.(map[string]interface{})), db.getParams()...)
 		} else {
-			logger.Fatal("invalid handle:", handle)
+			db.fatal("invalid handle:", handle)
 		}
 	case []map[string]interface{}:
 		var (
@@ -673,19 +1553,43 @@ func (db *DB) save(data interface{}, handle string) {
 		} else if handle == "update" {
 			query, args = db.updateGroup(data.([]map[string]interface{}))
 		} else {
-			logger.Fatal("invalid handle:", handle)
+			db.fatal("invalid handle:", handle)
+		}
+
+		if handle == "insert" {
+			db.lookupPK()
+		}
+
+		if db.dryRun() {
+			for i := 0; i < len(args); i++ {
+				db.record(query, args[i])
+			}
+
+			return
 		}
 
 		db.stmt = db.prepare(query)
 		defer db.stmtClose()
 
 		for i := 0; i < len(args); i++ {
-			if _, err := db.stmt.Exec(args[i]...); err != nil {
-				logger.Fatal(i, err)
+			start := time.Now()
+
+			res, err := db.stmt.ExecContext(db.context(), args[i]...)
+			if err != nil {
+				db.trace(start, query, args[i], 0, err)
+				db.fatal(fmt.Errorf("row %d: %w", i, err))
+			}
+
+			num, _ := res.RowsAffected()
+			db.trace(start, query, args[i], num, nil)
+
+			if handle == "insert" {
+				id, _ := res.LastInsertId()
+				db.fillPK(data.([]map[string]interface{})[i], id)
 			}
 		}
 	default:
-		logger.Fatal("invalid argument")
+		db.fatal("invalid argument")
 	}
 }
 
@@ -701,6 +1605,18 @@ func (db *DB) Update(data interface{}) {
 	db.save(data, "update")
 }
 
+func (db *DB) Increment(column string, amount interface{}, data ...map[string]interface{}) {
+	db.Exec(db.increment(column, "+", amount, data...), db.getParams()...)
+}
+
+func (db *DB) Decrement(column string, amount interface{}, data ...map[string]interface{}) {
+	db.Exec(db.increment(column, "-", amount, data...), db.getParams()...)
+}
+
+func (db *DB) UpdateByPK(id interface{}, data map[string]interface{}) {
+	db.Where([]interface{}{[]interface{}{db.primaryKey(), id}}).Update(data)
+}
+
 func (db *DB) TxUpdate(data map[string]interface{}) {
 	db.TxExec(db.update(data), db.getParams()...)
 }
@@ -720,7 +1636,7 @@ func (db *DB) delete() string {
 	}, "")
 
 	if db.config.Debug {
-		logger.Debug(query)
+		db.logger().Debug("build", "sql", query, "args", db.maskArgs(query, db.params))
 	}
 
 	return query
@@ -730,6 +1646,10 @@ func (db *DB) Delete() {
 	db.Exec(db.delete(), db.getParams()...)
 }
 
+func (db *DB) DeleteByPK(id interface{}) {
+	db.Where([]interface{}{[]interface{}{db.primaryKey(), id}}).Delete()
+}
+
 func (db *DB) TxDelete() {
 	db.TxExec(db.delete(), db.getParams()...)
 }
@@ -755,7 +1675,7 @@ func (db *DB) Create(name, charset string) {
 
 func (db *DB) Drop(name ...string) {
 	if len(name) > 1 {
-		logger.Fatal("too many arguments")
+		db.fatal("too many arguments")
 	} else if len(name) == 1 {
 		db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", name[0]))
 	} else {
@@ -765,7 +1685,7 @@ func (db *DB) Drop(name ...string) {
 
 func (db *DB) Alter(charset string, name ...string) {
 	if len(name) > 1 {
-		logger.Fatal("too many arguments")
+		db.fatal("too many arguments")
 	} else if len(name) == 1 {
 		db.Exec(strings.Join([]string{
 			"ALTER DATABASE ",
@@ -803,6 +1723,23 @@ func (db *DB) Truncate() {
 	db.Exec("TRUNCATE TABLE " + db.table)
 }
 
+func (db *DB) Stats() sql.DBStats {
+	db.syncPrimary()
+
+	return db.SQL.Stats()
+}
+
 func (db *DB) Close() {
+	if db.name != "" {
+		unregister(db)
+	}
+
+	db.closePools()
+}
+
+func (db *DB) closePools() {
+	db.replicas.close()
+	db.primaries.close()
+
 	_ = db.SQL.Close()
 }
\ No newline at end of file
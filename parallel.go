@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+)
+
+type Job func(q *DB) (interface{}, error)
+
+func (db *DB) run(job Job) (res interface{}, err error) {
+	defer catchFailure(&err)
+
+	return job(db)
+}
+
+func (db *DB) Parallel(limit int, jobs ...Job) ([]interface{}, error) {
+	if db.tx != nil {
+		db.fatal("parallel queries cannot run inside a transaction")
+	}
+
+	if limit < 1 || limit > len(jobs) {
+		limit = len(jobs)
+	}
+
+	ctx, cancel := context.WithCancel(db.context())
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	res := make([]interface{}, len(jobs))
+	sem := make(chan struct{}, limit)
+
+	fail := func(err error) {
+		once.Do(func() {
+			first = err
+			cancel()
+		})
+	}
+
+	for i := 0; i < len(jobs); i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				fail(ctx.Err())
+
+				return
+			}
+
+			defer func() {
+				<-sem
+			}()
+
+			q := db.clone()
+			q.ctx   = ctx
+			q.catch = true
+
+			v, err := q.run(jobs[i])
+			if err != nil {
+				fail(err)
+
+				return
+			}
+
+			res[i] = v
+		}(i)
+	}
+
+	wg.Wait()
+
+	if first != nil {
+		return nil, first
+	}
+
+	return res, nil
+}
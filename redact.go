@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"regexp"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+const Mask = "***"
+
+var DefaultMaskColumns = []string{"password", "passwd", "secret", "token", "card", "cvv"}
+
+var (
+	insertColumns = regexp.MustCompile("(?is)^\\s*(?:INSERT|REPLACE)\\s+INTO\\s+\\S+\\s*\\(([^)]*)\\)\\s*VALUES")
+	identifier    = regexp.MustCompile("[A-Za-z_][A-Za-z0-9_]*")
+	sqlKeywords   = map[string]bool{
+		"and": true, "or": true, "not": true, "in": true, "like": true, "between": true, "is": true,
+		"null": true, "values": true, "set": true, "where": true, "select": true, "limit": true,
+	}
+)
+
+func RedactDSN(dsn string) string {
+	dc, err := driver.ParseDSN(dsn)
+	if err != nil {
+		return Mask
+	}
+
+	if dc.Passwd != "" {
+		dc.Passwd = Mask
+	}
+
+	return dc.FormatDSN()
+}
+
+func masked(column string, patterns []string) bool {
+	column = strings.ToLower(column)
+
+	for i := 0; i < len(patterns); i++ {
+		if strings.Contains(column, strings.ToLower(patterns[i])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func MaskArgs(query string, args []interface{}, patterns []string) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+
+	if patterns == nil {
+		patterns = DefaultMaskColumns
+	}
+
+	res := make([]interface{}, len(args))
+	copy(res, args)
+
+	if m := insertColumns.FindStringSubmatch(query); m != nil {
+		cols := strings.Split(m[1], ",")
+
+		for i := 0; i < len(res); i++ {
+			if masked(FieldName(cols[i%len(cols)]), patterns) {
+				res[i] = Mask
+			}
+		}
+
+		return res
+	}
+
+	var (
+		n     int
+		quote byte
+	)
+
+	for i := 0; i < len(query) && n < len(res); i++ {
+		c := query[i]
+
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+
+			continue
+		}
+
+		if c != '?' {
+			continue
+		}
+
+		var col string
+
+		ids := identifier.FindAllString(query[:i], -1)
+		for j := len(ids) - 1; j >= 0; j-- {
+			if !sqlKeywords[strings.ToLower(ids[j])] {
+				col = ids[j]
+
+				break
+			}
+		}
+
+		if masked(col, patterns) {
+			res[n] = Mask
+		}
+
+		n++
+	}
+
+	return res
+}
+
+func (db *DB) maskArgs(query string, args []interface{}) []interface{} {
+	return MaskArgs(query, args, db.config.MaskColumns)
+}
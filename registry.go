@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"sort"
+	"sync"
+)
+
+var registry = struct {
+	sync.Mutex
+	def     string
+	configs map[string]*Config
+	conns   map[string]*DB
+}{
+	def:     "default",
+	configs: make(map[string]*Config),
+	conns:   make(map[string]*DB),
+}
+
+func Register(name string, cfg *Config) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if db, ok := registry.conns[name]; ok {
+		delete(registry.conns, name)
+		db.closePools()
+	}
+
+	if len(registry.configs) == 0 {
+		registry.def = name
+	}
+
+	registry.configs[name] = cfg
+}
+
+func RegisterAll(cfgs map[string]*Config) {
+	names := make([]string, 0, len(cfgs))
+	for k := range cfgs {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	registry.Lock()
+	empty := len(registry.configs) == 0
+	registry.Unlock()
+
+	for _, k := range names {
+		Register(k, cfgs[k])
+	}
+
+	if !empty {
+		return
+	}
+
+	for _, k := range []string{"default", "primary"} {
+		if _, ok := cfgs[k]; ok {
+			SetDefault(k)
+
+			return
+		}
+	}
+}
+
+func SetDefault(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.configs[name]; !ok {
+		fatal("connection " + name + " is not registered")
+	}
+
+	registry.def = name
+}
+
+func Conn(name ...string) *DB {
+	if len(name) > 1 {
+		fatal("too many arguments")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	key := registry.def
+	if len(name) == 1 {
+		key = name[0]
+	}
+
+	if db, ok := registry.conns[key]; ok {
+		return db
+	}
+
+	cfg, ok := registry.configs[key]
+	if !ok {
+		fatal("connection " + key + " is not registered")
+	}
+
+	db := New(cfg)
+	db.name = key
+
+	registry.conns[key] = db
+
+	return db
+}
+
+func CloseAll() {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for k, db := range registry.conns {
+		delete(registry.conns, k)
+		db.closePools()
+	}
+}
+
+func unregister(db *DB) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.conns[db.name] == db {
+		delete(registry.conns, db.name)
+	}
+}
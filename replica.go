@@ -0,0 +1,285 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Replica struct {
+	Host   string
+	Port   string
+	Weight int
+}
+
+type StatusSource interface {
+	Lag(ctx context.Context, host string, db *sql.DB) (time.Duration, error)
+}
+
+type ReplicaStatus struct{}
+
+type HeartbeatStatus struct {
+	Table  string
+	Column string
+}
+
+type replica struct {
+	db      *sql.DB
+	host    string
+	weight  int
+	current int
+	down    bool
+}
+
+type replicaSet struct {
+	sync.Mutex
+	nodes  []*replica
+	source StatusSource
+	maxLag time.Duration
+	log    Logger
+	stop   chan struct{}
+}
+
+func openReplicas(cfg *Config) *replicaSet {
+	if len(cfg.Replicas) == 0 {
+		return nil
+	}
+
+	rs := &replicaSet{source: ReplicaStatus{}, maxLag: cfg.MaxReplicaLag}
+
+	for _, r := range cfg.Replicas {
+		rc := *cfg
+		rc.Host     = r.Host
+		rc.Replicas = nil
+
+		if r.Port != "" {
+			rc.Port = r.Port
+		}
+
+		weight := r.Weight
+		if weight < 1 {
+			weight = 1
+		}
+
+		rs.nodes = append(rs.nodes, &replica{
+			db:     openPool(&rc),
+			host:   rc.Host + ":" + rc.Port,
+			weight: weight,
+		})
+	}
+
+	if cfg.HealthCheckInterval > 0 && !cfg.DryRun {
+		rs.stop = make(chan struct{})
+
+		go rs.watch(cfg.HealthCheckInterval)
+	}
+
+	return rs
+}
+
+func (ReplicaStatus) Lag(ctx context.Context, host string, db *sql.DB) (time.Duration, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return 0, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	fields, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+
+		return 0, errors.New(host + " is not a replica")
+	}
+
+	data := MakeArgs(len(fields))
+	if err = rows.Scan(data...); err != nil {
+		return 0, err
+	}
+
+	for i, f := range fields {
+		if f != "Seconds_Behind_Source" && f != "Seconds_Behind_Master" {
+			continue
+		}
+
+		if data[i] == nil {
+			return 0, errors.New(host + " replication is not running")
+		}
+
+		sec, err := time.ParseDuration(ItoS(data[i]) + "s")
+		if err != nil {
+			return 0, err
+		}
+
+		return sec, nil
+	}
+
+	return 0, errors.New(host + " reports no replication lag")
+}
+
+func (hs HeartbeatStatus) Lag(ctx context.Context, host string, db *sql.DB) (time.Duration, error) {
+	column := hs.Column
+	if column == "" {
+		column = "ts"
+	}
+
+	var lag float64
+
+	err := db.QueryRowContext(ctx, strings.Join([]string{
+		"SELECT TIMESTAMPDIFF(MICROSECOND, MAX(",
+		MakeBackQuote(column, " "),
+		"), UTC_TIMESTAMP(6)) FROM ",
+		MakeBackQuote(hs.Table, " "),
+	}, "")).Scan(&lag)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(lag) * time.Microsecond, nil
+}
+
+func (rs *replicaSet) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	rs.check(interval, nil)
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.check(interval, nil)
+		}
+	}
+}
+
+func (rs *replicaSet) check(timeout time.Duration, log Logger) {
+	rs.Lock()
+	source, maxLag := rs.source, rs.maxLag
+
+	if log == nil {
+		log = rs.log
+	}
+	rs.Unlock()
+
+	if log == nil {
+		log = logger
+	}
+
+	for _, r := range rs.nodes {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		lag, err := source.Lag(ctx, r.host, r.db)
+		cancel()
+
+		down := err != nil || (maxLag > 0 && lag > maxLag)
+
+		if err != nil {
+			log.Warn("replica is down", "host", r.host, "error", err)
+		} else if down {
+			log.Warn("replica is lagging", "host", r.host, "lag", lag)
+		}
+
+		rs.Lock()
+		r.down = down
+		rs.Unlock()
+	}
+}
+
+func (rs *replicaSet) next(primary *sql.DB) *sql.DB {
+	if rs == nil {
+		return primary
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	var (
+		best  *replica
+		total int
+	)
+
+	for _, r := range rs.nodes {
+		if r.down {
+			continue
+		}
+
+		r.current += r.weight
+		total     += r.weight
+
+		if best == nil || r.current > best.current {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return primary
+	}
+
+	best.current -= total
+
+	return best.db
+}
+
+func (rs *replicaSet) close() {
+	if rs == nil {
+		return
+	}
+
+	if rs.stop != nil {
+		close(rs.stop)
+		rs.stop = nil
+	}
+
+	for _, r := range rs.nodes {
+		_ = r.db.Close()
+	}
+}
+
+func (db *DB) SetStatusSource(src StatusSource) *DB {
+	if db.replicas != nil {
+		db.replicas.Lock()
+		db.replicas.source = src
+		db.replicas.Unlock()
+	}
+
+	return db
+}
+
+func (db *DB) CheckReplicas() {
+	if db.replicas == nil {
+		return
+	}
+
+	timeout := db.config.HealthCheckInterval
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	db.replicas.check(timeout, db.logger())
+}
+
+func (db *DB) UsePrimary() *DB {
+	db.master = true
+
+	return db
+}
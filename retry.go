@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"math/rand"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Retryable   func(err error) bool
+}
+
+func IsRetryable(err error) bool {
+	if IsDeadlock(err) || IsLockTimeout(err) {
+		return true
+	}
+
+	return errors.Is(err, sqldriver.ErrBadConn) || errors.Is(err, driver.ErrInvalidConn)
+}
+
+func (rp RetryPolicy) retryable(err error) bool {
+	if rp.Retryable != nil {
+		return rp.Retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	base, max := rp.BaseDelay, rp.MaxDelay
+
+	if base <= 0 {
+		base = 50 * time.Millisecond
+	}
+
+	if max <= 0 {
+		max = 2 * time.Second
+	}
+
+	delay := base << uint(attempt-1)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+func (rp RetryPolicy) do(ctx context.Context, log Logger, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= rp.MaxAttempts || !rp.retryable(err) {
+			return err
+		}
+
+		log.Warn("retrying", "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(rp.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (db *DB) attempt(fn func()) (err error) {
+	catch := db.catch
+	db.catch = true
+
+	defer func() {
+		db.catch = catch
+	}()
+
+	defer catchFailure(&err)
+
+	fn()
+
+	return
+}
+
+func (db *DB) retryRead(fn func()) {
+	if db.tx != nil {
+		fn()
+
+		return
+	}
+
+	master := db.master
+
+	err := db.config.Retry.do(db.context(), db.logger(), func() error {
+		db.master = master
+
+		return db.attempt(fn)
+	})
+
+	if err != nil {
+		db.fatal(err)
+	}
+}
@@ -0,0 +1,148 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Rows struct {
+	db     *DB
+	query  string
+	args   []interface{}
+	start  time.Time
+	count  int64
+	stmt   *sql.Stmt
+	rows   *sql.Rows
+	fields []string
+	err    error
+	closed bool
+}
+
+func (db *DB) Rows() *Rows {
+	var res *Rows
+
+	query := db.MakeSQL()
+	args  := db.getParams()
+
+	if db.dryRun() {
+		db.record(query, args)
+
+		return &Rows{db: db, query: query, args: args, closed: true}
+	}
+
+	db.retryRead(func() {
+		stmt  := db.prepareRead(query)
+		start := time.Now()
+
+		rows, err := stmt.QueryContext(db.context(), args...)
+		if err != nil {
+			_ = stmt.Close()
+			db.trace(start, query, args, 0, err)
+			db.fatal(err)
+		}
+
+		fields, err := rows.Columns()
+		if err != nil {
+			_ = rows.Close()
+			_ = stmt.Close()
+			db.fatal(err)
+		}
+
+		res = &Rows{
+			db:     db,
+			query:  query,
+			args:   args,
+			start:  start,
+			stmt:   stmt,
+			rows:   rows,
+			fields: fields,
+		}
+	})
+
+	return res
+}
+
+func (r *Rows) Columns() []string {
+	return r.fields
+}
+
+func (r *Rows) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	if r.rows.Next() {
+		r.count++
+
+		return true
+	}
+
+	r.err = r.rows.Err()
+	_ = r.Close()
+
+	return false
+}
+
+func (r *Rows) Scan(dest ...interface{}) error {
+	if err := r.rows.Scan(dest...); err != nil {
+		r.err = err
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *Rows) Map() (map[string]interface{}, error) {
+	data := MakeArgs(len(r.fields))
+
+	if err := r.Scan(data...); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]interface{}, len(r.fields))
+	for k, v := range data {
+		res[r.fields[k]] = v
+	}
+
+	return res, nil
+}
+
+func (r *Rows) Err() error {
+	return r.err
+}
+
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+
+	r.closed = true
+
+	err := r.rows.Close()
+	_ = r.stmt.Close()
+
+	r.db.trace(r.start, r.query, r.args, r.count, r.err)
+
+	return err
+}
+
+func (db *DB) Each(fn func(row map[string]interface{}) error) error {
+	rows := db.Rows()
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		row, err := rows.Map()
+		if err != nil {
+			return err
+		}
+
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,133 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SlowQuery struct {
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	Rows     int64
+	Caller   string
+	Plan     []string
+	Err      error
+}
+
+func caller() string {
+	pc := make([]uintptr, 16)
+	n  := runtime.Callers(3, pc)
+
+	frames := runtime.CallersFrames(pc[:n])
+
+	for {
+		f, more := frames.Next()
+
+		if !strings.HasPrefix(f.Function, "github.com/qkofy/mysql.") || strings.HasSuffix(f.File, "_test.go") {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+func (db *DB) explain(query string, args []interface{}) (plan []string) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if db.tx != nil {
+		rows, err = db.tx.QueryContext(ctx, "EXPLAIN "+query, args...)
+	} else {
+		db.syncPrimary()
+
+		rows, err = db.SQL.QueryContext(ctx, "EXPLAIN "+query, args...)
+	}
+
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	fields, _ := rows.Columns()
+
+	for rows.Next() {
+		data := MakeArgs(len(fields))
+
+		if err = rows.Scan(data...); err != nil {
+			break
+		}
+
+		row := make([]string, len(fields))
+		for k, v := range data {
+			row[k] = fields[k] + "=" + ItoS(v)
+		}
+
+		plan = append(plan, strings.Join(row, " "))
+	}
+
+	return
+}
+
+func (db *DB) trace(start time.Time, query string, args []interface{}, rows int64, err error) {
+	dur  := time.Since(start)
+	slow := db.config.SlowThreshold > 0 && dur >= db.config.SlowThreshold
+
+	if !db.config.Debug && !slow {
+		return
+	}
+
+	sq := SlowQuery{
+		SQL:      query,
+		Args:     db.maskArgs(query, args),
+		Duration: dur,
+		Rows:     rows,
+		Caller:   caller(),
+		Err:      err,
+	}
+
+	kv := []interface{}{
+		"sql", sq.SQL,
+		"args", sq.Args,
+		"duration", sq.Duration,
+		"rows", sq.Rows,
+		"caller", sq.Caller,
+	}
+
+	if err != nil {
+		kv = append(kv, "error", err)
+	}
+
+	if db.config.Debug {
+		db.logger().Debug("query", kv...)
+	}
+
+	if !slow {
+		return
+	}
+
+	if db.config.Explain && !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "EXPLAIN") {
+		sq.Plan = db.explain(query, args)
+		kv = append(kv, "plan", strings.Join(sq.Plan, "; "))
+	}
+
+	db.logger().Warn("slow query", kv...)
+
+	if db.config.SlowHook != nil {
+		db.config.SlowHook(sq)
+	}
+}